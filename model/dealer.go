@@ -25,6 +25,19 @@ type Dealer struct {
 	Country     string      `json:"country"`
 	PostalCode  string      `json:"postal_code"`
 	Brands      StringArray `json:"brands"`
+	Latitude    float64     `json:"latitude"`
+	Longitude   float64     `json:"longitude"`
+	// GeocodedAt is 0 until Latitude/Longitude have been populated by the
+	// geocoding job; a zero value means "not yet geocoded" rather than
+	// "located at (0, 0)".
+	GeocodedAt int64 `json:"geocoded_at"`
+}
+
+// DealerWithDistance pairs a Dealer with its great-circle distance, in
+// kilometers, from the point used in a SearchNear query.
+type DealerWithDistance struct {
+	Dealer
+	DistanceKm float64 `json:"distance_km"`
 }
 
 type DealerUpdate struct {
@@ -91,6 +104,19 @@ func (d *Dealer) IsValid() *AppError {
 		return InvalidDealerError("brands", d.Id)
 	}
 
+	// A zero GeocodedAt means the dealer hasn't been geocoded yet, so zero
+	// coordinates are expected and not an error. Once GeocodedAt is set, the
+	// coordinates must fall within valid ranges.
+	if d.GeocodedAt != 0 {
+		if d.Latitude < -90 || d.Latitude > 90 {
+			return InvalidDealerError("latitude", d.Id)
+		}
+
+		if d.Longitude < -180 || d.Longitude > 180 {
+			return InvalidDealerError("longitude", d.Id)
+		}
+	}
+
 	return nil
 }
 
@@ -167,6 +193,48 @@ func (d *Dealer) Patch(patch *DealerPatch) {
 	}
 }
 
+// Sanitize strips fields a caller isn't entitled to see. options carries one
+// key per sanitizable field ("phone_number", "address", "postal_code"); a
+// true value leaves that field as-is, and a missing key is treated as false.
+// The zero value of options therefore strips everything.
+func (d *Dealer) Sanitize(options map[string]bool) {
+	if !options["phone_number"] {
+		d.PhoneNumber = ""
+	}
+
+	if !options["address"] {
+		d.Address = ""
+	}
+
+	if !options["postal_code"] {
+		d.PostalCode = ""
+	}
+}
+
+// DealerSanitizeOptions computes the options Dealer.Sanitize expects for a
+// caller who either holds the org-wide manage_dealers permission, or is the
+// self dealer admin for this specific dealer — e.g. the dealer's own owner
+// account, who can see their own full record despite lacking the blanket
+// permission. Either one is enough to see everything.
+func DealerSanitizeOptions(hasManageDealers, isSelfDealerAdmin bool) map[string]bool {
+	if !hasManageDealers && !isSelfDealerAdmin {
+		return map[string]bool{}
+	}
+
+	return map[string]bool{
+		"phone_number": true,
+		"address":      true,
+		"postal_code":  true,
+	}
+}
+
+// SanitizeForNotAdmin strips the fields a caller without the manage_dealers
+// permission shouldn't see, unless isSelfDealerAdmin is true, in which case
+// the caller is the dealer's own owner account and keeps full access.
+func (d *Dealer) SanitizeForNotAdmin(isSelfDealerAdmin bool) {
+	d.Sanitize(DealerSanitizeOptions(false, isSelfDealerAdmin))
+}
+
 // ToJson convert a Dealer to a json string
 func (d *Dealer) ToJson() string {
 	b, _ := json.Marshal(d)
@@ -208,6 +276,68 @@ func IsValidDealerName(s string) bool {
 	return true
 }
 
+const (
+	DEALER_SEARCH_DEFAULT_PAGE     = 0
+	DEALER_SEARCH_DEFAULT_PER_PAGE = 60
+	DEALER_SEARCH_MAX_PER_PAGE     = 200
+
+	DEALER_SORT_BY_NAME       = "name"
+	DEALER_SORT_BY_UPDATED_AT = "updated_at"
+)
+
+// DealerSearchOptions narrows down DealerStore.SearchDealers. Term matches
+// against Name and Address; the other set filters are ANDed with it: City,
+// Province and Country match exactly, PostalCodePrefix by prefix, Brands
+// matches any of the listed brands, and UpdatedAfter keeps later UpdateAt.
+type DealerSearchOptions struct {
+	Term             string
+	Brands           []string
+	City             string
+	Province         string
+	Country          string
+	PostalCodePrefix string
+	UpdatedAfter     int64
+	Page             int
+	PerPage          int
+	SortBy           string
+}
+
+// DealerSearchResults is the paginated response from DealerStore.SearchDealers.
+type DealerSearchResults struct {
+	Dealers    DealerSlice `json:"dealers"`
+	TotalCount int64       `json:"total_count"`
+}
+
+const (
+	DEALER_BULK_IMPORT_FORMAT_CSV   = "csv"
+	DEALER_BULK_IMPORT_FORMAT_JSONL = "jsonl"
+
+	DEALER_BULK_IMPORT_BATCH_SIZE = 500
+)
+
+// DealerBulkImportOptions configures DealerStore.BulkImport.
+type DealerBulkImportOptions struct {
+	// Upsert matches incoming rows against existing dealers by
+	// (Name, City, Province) and updates them in place instead of inserting
+	// a second row, since OEM feeds rarely carry a Mattermost Id.
+	Upsert bool
+}
+
+// DealerImportRowError records a single row that BulkImport couldn't import,
+// keeping the line number so the caller can point the feed owner at it.
+type DealerImportRowError struct {
+	Line  int       `json:"line"`
+	Error *AppError `json:"error"`
+	Row   string    `json:"row,omitempty"`
+}
+
+// DealerBulkImportResult is returned by DealerStore.BulkImport.
+type DealerBulkImportResult struct {
+	Created int                     `json:"created"`
+	Updated int                     `json:"updated"`
+	Errors  []*DealerImportRowError `json:"errors"`
+}
+
 type DealerSlice []*Dealer
 
 func (d DealerSlice) FilterByName(names []string) DealerSlice {
@@ -221,3 +351,11 @@ func (d DealerSlice) FilterByName(names []string) DealerSlice {
 	}
 	return DealerSlice(matches)
 }
+
+// Sanitize sanitizes every dealer in the slice in place, so list endpoints
+// don't have to loop over Sanitize themselves.
+func (d DealerSlice) Sanitize(options map[string]bool) {
+	for _, dealer := range d {
+		dealer.Sanitize(options)
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDealer() *Dealer {
+	return &Dealer{
+		Id:          NewId(),
+		CreateAt:    1,
+		UpdateAt:    1,
+		Name:        "Test Dealer",
+		PhoneNumber: "555-0100",
+		Address:     "123 Main St",
+		City:        "Springfield",
+		Province:    "ON",
+		Country:     "CA",
+		PostalCode:  "A1A 1A1",
+		Brands:      StringArray{"Acme"},
+	}
+}
+
+func TestDealerPatch(t *testing.T) {
+	dealer := testDealer()
+	newName := "Patched Dealer"
+	newBrands := StringArray{"Acme", "Globex"}
+
+	dealer.Patch(&DealerPatch{
+		Name:   &newName,
+		Brands: &newBrands,
+	})
+
+	require.Equal(t, newName, dealer.Name)
+	require.Equal(t, newBrands, dealer.Brands)
+	// Fields not present on the patch are left untouched.
+	require.Equal(t, "Springfield", dealer.City)
+}
+
+func TestDealerSanitize(t *testing.T) {
+	t.Run("no permission strips everything", func(t *testing.T) {
+		dealer := testDealer()
+		dealer.Sanitize(DealerSanitizeOptions(false, false))
+
+		require.Empty(t, dealer.PhoneNumber)
+		require.Empty(t, dealer.Address)
+		require.Empty(t, dealer.PostalCode)
+	})
+
+	t.Run("manage_dealers permission keeps everything", func(t *testing.T) {
+		dealer := testDealer()
+		dealer.Sanitize(DealerSanitizeOptions(true, false))
+
+		require.Equal(t, "555-0100", dealer.PhoneNumber)
+		require.Equal(t, "123 Main St", dealer.Address)
+		require.Equal(t, "A1A 1A1", dealer.PostalCode)
+	})
+
+	t.Run("self dealer admin keeps everything despite lacking the permission", func(t *testing.T) {
+		dealer := testDealer()
+		dealer.Sanitize(DealerSanitizeOptions(false, true))
+
+		require.Equal(t, "555-0100", dealer.PhoneNumber)
+		require.Equal(t, "123 Main St", dealer.Address)
+		require.Equal(t, "A1A 1A1", dealer.PostalCode)
+	})
+}
+
+func TestDealerSanitizeForNotAdmin(t *testing.T) {
+	dealer := testDealer()
+	dealer.SanitizeForNotAdmin(false)
+	require.Empty(t, dealer.PhoneNumber)
+
+	dealer = testDealer()
+	dealer.SanitizeForNotAdmin(true)
+	require.Equal(t, "555-0100", dealer.PhoneNumber)
+}
+
+func TestDealerSliceSanitize(t *testing.T) {
+	dealers := DealerSlice{testDealer(), testDealer()}
+	dealers.Sanitize(DealerSanitizeOptions(false, false))
+
+	for _, dealer := range dealers {
+		require.Empty(t, dealer.PhoneNumber)
+		require.Empty(t, dealer.Address)
+		require.Empty(t, dealer.PostalCode)
+	}
+}
@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/require"
+)
+
+// These cover the Save/Update failure paths that return before ever touching
+// the database, so they can run against a zero-value SqlDealerStore. The
+// paths that fail inside a DB call (Get's sql.ErrNoRows, GetAll/Update's
+// query errors) aren't reachable without a DB test harness, which this tree
+// doesn't have.
+func TestSqlDealerStoreFailurePaths(t *testing.T) {
+	var ds SqlDealerStore
+
+	validDealer := func() *model.Dealer {
+		return &model.Dealer{
+			Name:        "Acme Motors",
+			PhoneNumber: "555-0100",
+			Address:     "123 Main St",
+			City:        "Springfield",
+			Province:    "ON",
+			Country:     "CA",
+			PostalCode:  "A1A 1A1",
+			Brands:      model.StringArray{"Acme"},
+		}
+	}
+
+	cases := []struct {
+		name           string
+		run            func() *model.AppError
+		expectedId     string
+		expectedStatus int
+	}{
+		{
+			name: "Save rejects a dealer with a caller-supplied Id",
+			run: func() *model.AppError {
+				dealer := validDealer()
+				dealer.Id = model.NewId()
+				_, appErr := ds.Save(dealer)
+				return appErr
+			},
+			expectedId:     "store.sql_dealer.save.existing.app_error",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Save rejects an invalid dealer",
+			run: func() *model.AppError {
+				dealer := validDealer()
+				dealer.Name = ""
+				_, appErr := ds.Save(dealer)
+				return appErr
+			},
+			expectedId:     "model.dealer.is_valid.name.app_error",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Update rejects an invalid dealer",
+			run: func() *model.AppError {
+				dealer := validDealer()
+				dealer.Id = model.NewId()
+				dealer.CreateAt = 1
+				dealer.Name = ""
+				_, appErr := ds.Update(dealer, false)
+				return appErr
+			},
+			expectedId:     "model.dealer.is_valid.name.app_error",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			appErr := c.run()
+			require.NotNil(t, appErr)
+			require.Equal(t, c.expectedId, appErr.Id)
+			require.Equal(t, c.expectedStatus, appErr.StatusCode)
+		})
+	}
+}
@@ -1,15 +1,25 @@
 package sqlstore
 
 import (
+	"bufio"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
 	sq "github.com/Masterminds/squirrel"
 	"github.com/mattermost/mattermost-server/v5/einterfaces"
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/store"
-	"net/http"
 )
 
+// earthRadiusKm is the radius used for the Haversine distance calculation in SearchNear.
+const earthRadiusKm = 6371
+
 var (
 	DEALER_SEARCH_TYPE_NAME = []string{"Name"}
 )
@@ -33,7 +43,8 @@ func newSqlDealerStore(sqlStore SqlStore, metrics einterfaces.MetricsInterface)
 	// note: we are providing field names explicitly here to maintain order of columns (needed when using raw queries)
 	ds.dealersQuery = ds.getQueryBuilder().
 		Select("d.Id", "d.CreateAt", "d.UpdateAt", "d.DeleteAt", "d.Name", "d.PhoneNumber", "d.Address",
-			"d.City", "d.Province", "d.Country", "d.PostalCode", "d.Brands").
+			"d.City", "d.Province", "d.Country", "d.PostalCode", "d.Brands", "d.Latitude", "d.Longitude",
+			"d.GeocodedAt").
 		From("Dealer d")
 
 	for _, db := range sqlStore.GetAllConns() {
@@ -53,54 +64,159 @@ func newSqlDealerStore(sqlStore SqlStore, metrics einterfaces.MetricsInterface)
 }
 
 func (ds SqlDealerStore) createIndexesIfNotExists() {
+	// Must run before the composite index below: on an upgrade (as opposed
+	// to a fresh install) these columns don't exist yet, and dealersQuery
+	// already selects them unconditionally.
+	ds.migrateGeocodingColumns()
+
 	ds.CreateIndexIfNotExists("idx_dealer_update_at", "Dealer", "UpdateAt")
 	ds.CreateIndexIfNotExists("idx_dealer_create_at", "Dealer", "CreateAt")
 	ds.CreateIndexIfNotExists("idx_dealer_delete_at", "Dealer", "DeleteAt")
+	ds.CreateCompositeIndexIfNotExists("idx_dealer_latitude_longitude", "Dealer", []string{"Latitude", "Longitude"})
+}
+
+// migrateGeocodingColumns adds the Latitude, Longitude, and GeocodedAt
+// columns to pre-existing Dealer tables that predate geocoding support.
+func (ds SqlDealerStore) migrateGeocodingColumns() {
+	ds.CreateColumnIfNotExists("Dealer", "Latitude", "double precision", "double precision", "0")
+	ds.CreateColumnIfNotExists("Dealer", "Longitude", "double precision", "double precision", "0")
+	ds.CreateColumnIfNotExists("Dealer", "GeocodedAt", "bigint", "bigint", "0")
+}
+
+// dealerSelectColumnsSQL lists the Dealer columns in the order model.Dealer's
+// fields are declared, for the raw named-parameter queries below.
+const dealerSelectColumnsSQL = "Id, CreateAt, UpdateAt, DeleteAt, Name, PhoneNumber, Address, City, Province, " +
+	"Country, PostalCode, Brands, Latitude, Longitude, GeocodedAt"
+
+// sanitizeDealer is the single choke point outbound dealers pass through
+// before leaving the store, so Get/GetAll/SearchDealers can't forget it.
+// sanitizeOptions is the map model.Dealer.Sanitize expects; pass an all-true
+// map (model.DealerSanitizeOptions(true, false)) for callers entitled to the
+// unsanitized record, such as BulkExport. A nil map strips every field.
+func sanitizeDealer(dealer *model.Dealer, sanitizeOptions map[string]bool) *model.Dealer {
+	if dealer != nil {
+		dealer.Sanitize(sanitizeOptions)
+	}
+	return dealer
+}
+
+func sanitizeDealers(dealers model.DealerSlice, sanitizeOptions map[string]bool) model.DealerSlice {
+	dealers.Sanitize(sanitizeOptions)
+	return dealers
 }
 
-// Get fetches the given dealer in the database.
-func (ds SqlDealerStore) Get(id string) (*model.Dealer, *model.AppError) {
-	failure := func(err error, id string, statusCode int) *model.AppError {
+// Get fetches the given dealer in the database. Soft-deleted dealers are
+// excluded unless includeDeleted is true. sanitizeOptions is applied to the
+// result via sanitizeDealer before it's returned.
+func (ds SqlDealerStore) Get(id string, includeDeleted bool, sanitizeOptions map[string]bool) (*model.Dealer, *model.AppError) {
+	failure := func(err error, errId string, statusCode int) *model.AppError {
 		details := "dealer_id=" + id + ", " + err.Error()
-		return model.NewAppError("SqlDealerStore.Get", id, nil, details, statusCode)
+		return model.NewAppError("SqlDealerStore.Get", errId, nil, details, statusCode)
 	}
 
-	query := ds.dealersQuery.Where("Id = ?", id)
-	queryString, args, err := query.ToSql()
-	if err != nil {
-		return nil, failure(err, "store.sql_dealer.get.app_error", http.StatusInternalServerError)
+	query := "SELECT " + dealerSelectColumnsSQL + " FROM Dealer WHERE Id = :Id"
+	if !includeDeleted {
+		query += " AND DeleteAt = 0"
 	}
-	row := ds.GetReplica().Db.QueryRow(queryString, args...)
 
 	var dealer model.Dealer
-	err = row.Scan(&dealer.Id, &dealer.CreateAt, &dealer.UpdateAt, &dealer.DeleteAt, &dealer.Name,
-		&dealer.PhoneNumber, &dealer.Address, &dealer.City, &dealer.Province, &dealer.Country,
-		&dealer.PostalCode, &dealer.Brands)
-	if err != nil {
+	if err := ds.GetReplica().SelectOne(&dealer, query, map[string]interface{}{"Id": id}); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, failure(err, store.MISSING_ACCOUNT_ERROR, http.StatusNotFound)
 		}
 		return nil, failure(err, "store.sql_dealer.get.app_error", http.StatusInternalServerError)
+	}
+
+	return sanitizeDealer(&dealer, sanitizeOptions), nil
+}
 
+// GetAll fetches from all dealers in the database. Soft-deleted dealers are
+// excluded unless includeDeleted is true. sanitizeOptions is applied to
+// every result via sanitizeDealers before they're returned.
+func (ds SqlDealerStore) GetAll(includeDeleted bool, sanitizeOptions map[string]bool) ([]*model.Dealer, *model.AppError) {
+	query := "SELECT " + dealerSelectColumnsSQL + " FROM Dealer"
+	params := map[string]interface{}{"DeleteAt": 0}
+	if !includeDeleted {
+		query += " WHERE DeleteAt = :DeleteAt"
 	}
+	query += " ORDER BY Name ASC"
 
-	return &dealer, nil
+	var data []*model.Dealer
+	if _, err := ds.GetReplica().Select(&data, query, params); err != nil {
+		return nil, model.NewAppError("SqlDealerStore.GetAll", "store.sql_dealer.get_all.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return sanitizeDealers(data, sanitizeOptions), nil
 }
 
-// GetAll fetches from all dealers in the database.
-func (ds SqlDealerStore) GetAll() ([]*model.Dealer, *model.AppError) {
-	query := ds.dealersQuery.OrderBy("Name ASC")
+// execNamedUpdate runs an UPDATE built from a column => value map, so every
+// mutating helper names each column next to its value instead of hand-writing
+// positional "?" placeholders that are easy to miscount as columns are added.
+func (ds SqlDealerStore) execNamedUpdate(set map[string]interface{}, where sq.Sqlizer) (int64, error) {
+	queryString, args, err := ds.getQueryBuilder().
+		Update("Dealer").
+		SetMap(set).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
 
-	queryString, args, err := query.ToSql()
+	sqlResult, err := ds.GetMaster().Exec(queryString, args...)
 	if err != nil {
-		return nil, model.NewAppError("SqlDealerStore.GetAll", "store.sql_dealer.app_error", nil, err.Error(), http.StatusInternalServerError)
+		return 0, err
 	}
 
-	var data []*model.Dealer
-	if _, err := ds.GetReplica().Select(&data, queryString, args...); err != nil {
-		return nil, model.NewAppError("SqlDealerStore.GetAll", "store.sql_dealer.get.app_error", nil, err.Error(), http.StatusInternalServerError)
+	return sqlResult.RowsAffected()
+}
+
+// Delete soft-deletes the given dealer by setting DeleteAt, leaving the row
+// in place for Restore or PermanentDelete.
+func (ds SqlDealerStore) Delete(id string, time int64) *model.AppError {
+	count, err := ds.execNamedUpdate(
+		map[string]interface{}{"DeleteAt": time, "UpdateAt": time},
+		sq.And{sq.Eq{"Id": id}, sq.Eq{"DeleteAt": 0}})
+	if err != nil {
+		return model.NewAppError("SqlDealerStore.Delete", "store.sql_dealer.delete.app_error", nil, "dealer_id="+id+", "+err.Error(), http.StatusInternalServerError)
 	}
-	return data, nil
+	if count != 1 {
+		return model.NewAppError("SqlDealerStore.Delete", "store.sql_dealer.delete.app_error", nil, "dealer_id="+id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// Restore reverses a soft-delete, clearing DeleteAt and bumping UpdateAt.
+func (ds SqlDealerStore) Restore(id string) *model.AppError {
+	now := model.GetMillis()
+	count, err := ds.execNamedUpdate(
+		map[string]interface{}{"DeleteAt": 0, "UpdateAt": now},
+		sq.And{sq.Eq{"Id": id}, sq.NotEq{"DeleteAt": 0}})
+	if err != nil {
+		return model.NewAppError("SqlDealerStore.Restore", "store.sql_dealer.restore.app_error", nil, "dealer_id="+id+", "+err.Error(), http.StatusInternalServerError)
+	}
+	if count != 1 {
+		return model.NewAppError("SqlDealerStore.Restore", "store.sql_dealer.restore.app_error", nil, "dealer_id="+id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// PermanentDelete removes the dealer row entirely. Unlike Delete, this cannot
+// be undone with Restore.
+func (ds SqlDealerStore) PermanentDelete(id string) *model.AppError {
+	queryString, args, err := ds.getQueryBuilder().
+		Delete("Dealer").
+		Where(sq.Eq{"Id": id}).
+		ToSql()
+	if err != nil {
+		return model.NewAppError("SqlDealerStore.PermanentDelete", "store.sql_dealer.permanent_delete.app_error", nil, "dealer_id="+id+", "+err.Error(), http.StatusInternalServerError)
+	}
+
+	if _, err := ds.GetMaster().Exec(queryString, args...); err != nil {
+		return model.NewAppError("SqlDealerStore.PermanentDelete", "store.sql_dealer.permanent_delete.app_error", nil, "dealer_id="+id+", "+err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
 }
 
 // Save persists a new dealer to the database.
@@ -121,6 +237,21 @@ func (ds SqlDealerStore) Save(dealer *model.Dealer) (*model.Dealer, *model.AppEr
 	return dealer, nil
 }
 
+// applyUpdateOwnership reasserts the fields dealer must not be allowed to
+// change via a plain Update: CreateAt is always server-owned, and Id/DeleteAt
+// are too unless trustedUpdateData says the caller is allowed to set them
+// explicitly. Every other field - including whatever a
+// prior Dealer.Patch applied - is left exactly as the caller sent it; this is
+// the fix for Update previously copying every mutable field back from
+// oldDealer and silently discarding patched changes.
+func applyUpdateOwnership(dealer, oldDealer *model.Dealer, trustedUpdateData bool) {
+	dealer.CreateAt = oldDealer.CreateAt
+	if !trustedUpdateData {
+		dealer.Id = oldDealer.Id
+		dealer.DeleteAt = oldDealer.DeleteAt
+	}
+}
+
 // Update persists an updated dealer to the database.
 func (ds SqlDealerStore) Update(dealer *model.Dealer, trustedUpdateData bool) (*model.DealerUpdate, *model.AppError) {
 	dealer.PreUpdate()
@@ -139,15 +270,11 @@ func (ds SqlDealerStore) Update(dealer *model.Dealer, trustedUpdateData bool) (*
 	}
 
 	oldDealer := oldDealerResult.(*model.Dealer)
-	dealer.CreateAt = oldDealer.CreateAt
-	dealer.Name = oldDealer.Name
-	dealer.PhoneNumber = oldDealer.PhoneNumber
-	dealer.Address = oldDealer.Address
-	dealer.City = oldDealer.City
-	dealer.Province = oldDealer.Province
-	dealer.Country = oldDealer.Country
-	dealer.PostalCode = oldDealer.PostalCode
-	dealer.Brands = oldDealer.Brands
+	if oldDealer.DeleteAt != 0 {
+		return nil, model.NewAppError("SqlDealerStore.Update", "store.sql_dealer.update.deleted.app_error", nil, "dealer_id="+dealer.Id, http.StatusBadRequest)
+	}
+
+	applyUpdateOwnership(dealer, oldDealer, trustedUpdateData)
 
 	count, err := ds.GetMaster().Update(dealer)
 	if err != nil {
@@ -160,3 +287,499 @@ func (ds SqlDealerStore) Update(dealer *model.Dealer, trustedUpdateData bool) (*
 
 	return &model.DealerUpdate{New: dealer, Old: oldDealer}, nil
 }
+
+// Patch loads the dealer, applies patch on top of it, and writes the result
+// back inside a transaction so two concurrent patches can't clobber each
+// other's fields the way two concurrent Update calls could.
+func (ds SqlDealerStore) Patch(id string, patch *model.DealerPatch) (*model.Dealer, *model.AppError) {
+	transaction, err := ds.GetMaster().Begin()
+	if err != nil {
+		return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.patch.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer finalizeTransaction(transaction)
+
+	// FOR UPDATE locks the row until commit, so a concurrent Patch waits and
+	// then reads this one's result instead of the pre-patch row.
+	dealer := &model.Dealer{}
+	query := "SELECT " + dealerSelectColumnsSQL + " FROM Dealer WHERE Id = :Id FOR UPDATE"
+	if err := transaction.SelectOne(dealer, query, map[string]interface{}{"Id": id}); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.update.find.app_error", nil, "dealer_id="+id, http.StatusBadRequest)
+		}
+		return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.update.finding.app_error", nil, "dealer_id="+id+", "+err.Error(), http.StatusInternalServerError)
+	}
+
+	if dealer.DeleteAt != 0 {
+		return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.update.deleted.app_error", nil, "dealer_id="+id, http.StatusBadRequest)
+	}
+
+	dealer.Patch(patch)
+	dealer.PreUpdate()
+
+	if err := dealer.IsValid(); err != nil {
+		return nil, err
+	}
+
+	count, err := transaction.Update(dealer)
+	if err != nil {
+		return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.update.updating.app_error", nil, "dealer_id="+id+", "+err.Error(), http.StatusInternalServerError)
+	}
+	if count != 1 {
+		return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.update.app_error", nil, fmt.Sprintf("dealer_id=%v, count=%v", id, count), http.StatusInternalServerError)
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return nil, model.NewAppError("SqlDealerStore.Patch", "store.sql_dealer.patch.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return dealer, nil
+}
+
+// SearchNear returns dealers within radiusKm of (lat, lon), nearest first,
+// optionally restricted to the given brands. Distance is computed with the
+// Haversine formula directly in SQL so only the rows that pass the cheap
+// bounding-box pre-filter need the trig math applied to them. sanitizeOptions
+// is applied to every result before it's returned, same as Get/GetAll/SearchDealers.
+func (ds SqlDealerStore) SearchNear(lat, lon, radiusKm float64, brands []string, limit int, sanitizeOptions map[string]bool) ([]*model.DealerWithDistance, *model.AppError) {
+	// delta is the bounding-box half-width in degrees of latitude; the
+	// longitude half-width is widened by 1/cos(lat) to account for
+	// meridians converging away from the equator.
+	delta := radiusKm / 111
+	lonDelta := delta / math.Cos(lat*math.Pi/180)
+
+	// Alias as DistanceKm, not just "distance": gorp's Select maps result
+	// columns to struct fields by name, and model.DealerWithDistance's field
+	// is DistanceKm.
+	query := ds.dealersQuery.
+		Column(sq.Alias(sq.Expr(
+			"? * 2 * asin(sqrt(power(sin(radians(d.Latitude - ?) / 2), 2) + "+
+				"cos(radians(?)) * cos(radians(d.Latitude)) * power(sin(radians(d.Longitude - ?) / 2), 2)))",
+			earthRadiusKm, lat, lat, lon), "DistanceKm")).
+		Where("d.DeleteAt = 0").
+		Where("d.GeocodedAt != 0").
+		Where(sq.GtOrEq{"d.Latitude": lat - delta}).
+		Where(sq.LtOrEq{"d.Latitude": lat + delta}).
+		Where(sq.GtOrEq{"d.Longitude": lon - lonDelta}).
+		Where(sq.LtOrEq{"d.Longitude": lon + lonDelta}).
+		Having("DistanceKm <= ?", radiusKm).
+		OrderBy("DistanceKm ASC").
+		Limit(uint64(limit))
+
+	if len(brands) > 0 {
+		query = query.Where(ds.brandsIn(brands))
+	}
+
+	queryString, args, err := query.ToSql()
+	if err != nil {
+		return nil, model.NewAppError("SqlDealerStore.SearchNear", "store.sql_dealer.search_near.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	var data []*model.DealerWithDistance
+	if _, err := ds.GetReplica().Select(&data, queryString, args...); err != nil {
+		return nil, model.NewAppError("SqlDealerStore.SearchNear", "store.sql_dealer.search_near.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	for _, dealerWithDistance := range data {
+		dealerWithDistance.Dealer.Sanitize(sanitizeOptions)
+	}
+
+	return data, nil
+}
+
+// brandsIn matches dealers carrying any of the given brands. Brands is a
+// StringArray persisted as serialized JSON rather than its own table, so
+// membership is approximated with LIKE until brands are normalized out.
+func (ds SqlDealerStore) brandsIn(brands []string) sq.Sqlizer {
+	filter := sq.Or{}
+	for _, brand := range brands {
+		filter = append(filter, sq.Like{"d.Brands": "%\"" + brand + "\"%"})
+	}
+	return filter
+}
+
+// dealerSearchPredicates builds the shared WHERE predicates for
+// SearchDealers so the row query and the COUNT(*) query stay in sync.
+func (ds SqlDealerStore) dealerSearchPredicates(opts *model.DealerSearchOptions) sq.And {
+	likeOp := "LIKE"
+	if ds.DriverName() == model.DATABASE_DRIVER_POSTGRES {
+		likeOp = "ILIKE"
+	}
+
+	predicates := sq.And{sq.Eq{"d.DeleteAt": 0}}
+
+	if opts.Term != "" {
+		term := "%" + opts.Term + "%"
+		predicates = append(predicates, sq.Or{
+			sq.Expr("d.Name "+likeOp+" ?", term),
+			sq.Expr("d.Address "+likeOp+" ?", term),
+		})
+	}
+
+	if len(opts.Brands) > 0 {
+		predicates = append(predicates, ds.brandsIn(opts.Brands))
+	}
+
+	if opts.City != "" {
+		predicates = append(predicates, sq.Eq{"d.City": opts.City})
+	}
+
+	if opts.Province != "" {
+		predicates = append(predicates, sq.Eq{"d.Province": opts.Province})
+	}
+
+	if opts.Country != "" {
+		predicates = append(predicates, sq.Eq{"d.Country": opts.Country})
+	}
+
+	if opts.PostalCodePrefix != "" {
+		predicates = append(predicates, sq.Expr("d.PostalCode "+likeOp+" ?", opts.PostalCodePrefix+"%"))
+	}
+
+	if opts.UpdatedAfter > 0 {
+		predicates = append(predicates, sq.Gt{"d.UpdateAt": opts.UpdatedAfter})
+	}
+
+	return predicates
+}
+
+// SearchDealers returns a page of dealers matching opts, along with the
+// total count of matching rows across all pages. Results are sanitized per
+// sanitizeOptions via sanitizeDealers before being returned.
+func (ds SqlDealerStore) SearchDealers(opts *model.DealerSearchOptions, sanitizeOptions map[string]bool) (*model.DealerSearchResults, *model.AppError) {
+	predicates := ds.dealerSearchPredicates(opts)
+
+	sortBy := "d.Name ASC"
+	if opts.SortBy == model.DEALER_SORT_BY_UPDATED_AT {
+		sortBy = "d.UpdateAt DESC"
+	}
+
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = model.DEALER_SEARCH_DEFAULT_PER_PAGE
+	}
+	if perPage > model.DEALER_SEARCH_MAX_PER_PAGE {
+		perPage = model.DEALER_SEARCH_MAX_PER_PAGE
+	}
+
+	query := ds.dealersQuery.
+		Where(predicates).
+		OrderBy(sortBy).
+		Limit(uint64(perPage)).
+		Offset(uint64(opts.Page) * uint64(perPage))
+
+	queryString, args, err := query.ToSql()
+	if err != nil {
+		return nil, model.NewAppError("SqlDealerStore.SearchDealers", "store.sql_dealer.search.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	var dealers model.DealerSlice
+	if _, err := ds.GetReplica().Select(&dealers, queryString, args...); err != nil {
+		return nil, model.NewAppError("SqlDealerStore.SearchDealers", "store.sql_dealer.search.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	countQuery := ds.getQueryBuilder().
+		Select("COUNT(*)").
+		From("Dealer d").
+		Where(predicates)
+
+	countQueryString, countArgs, err := countQuery.ToSql()
+	if err != nil {
+		return nil, model.NewAppError("SqlDealerStore.SearchDealers", "store.sql_dealer.search.count.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	totalCount, err := ds.GetReplica().SelectInt(countQueryString, countArgs...)
+	if err != nil {
+		return nil, model.NewAppError("SqlDealerStore.SearchDealers", "store.sql_dealer.search.count.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return &model.DealerSearchResults{Dealers: sanitizeDealers(dealers, sanitizeOptions), TotalCount: totalCount}, nil
+}
+
+// dealerCSVColumns is the header BulkImport/BulkExport expect for the "csv"
+// format. Brands is serialized as a ";"-separated list within its column.
+var dealerCSVColumns = []string{"Name", "PhoneNumber", "Address", "City", "Province", "Country", "PostalCode", "Brands"}
+
+func dealerFromCSVRow(header, row []string) *model.Dealer {
+	dealer := &model.Dealer{}
+	for i, column := range header {
+		if i >= len(row) {
+			break
+		}
+		value := row[i]
+		switch column {
+		case "Name":
+			dealer.Name = value
+		case "PhoneNumber":
+			dealer.PhoneNumber = value
+		case "Address":
+			dealer.Address = value
+		case "City":
+			dealer.City = value
+		case "Province":
+			dealer.Province = value
+		case "Country":
+			dealer.Country = value
+		case "PostalCode":
+			dealer.PostalCode = value
+		case "Brands":
+			if value != "" {
+				dealer.Brands = strings.Split(value, ";")
+			}
+		}
+	}
+	return dealer
+}
+
+// BulkImport streams format ("csv" or "jsonl") rows from r, validating and
+// persisting each one without ever holding the whole file in memory. Rows
+// are committed in batches of DEALER_BULK_IMPORT_BATCH_SIZE so a feed with
+// one bad row doesn't roll back everything that imported cleanly. The
+// returned result always reflects every batch committed so far, even when
+// the error comes from a batch-level failure (e.g. a dropped connection)
+// rather than a single row - callers shouldn't lose visibility into rows
+// that already succeeded.
+func (ds SqlDealerStore) BulkImport(r io.Reader, format string, opts model.DealerBulkImportOptions) (*model.DealerBulkImportResult, *model.AppError) {
+	result := &model.DealerBulkImportResult{}
+
+	batch := make([]*model.Dealer, 0, model.DEALER_BULK_IMPORT_BATCH_SIZE)
+	lines := make([]int, 0, model.DEALER_BULK_IMPORT_BATCH_SIZE)
+
+	flush := func() *model.AppError {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := ds.bulkImportBatch(batch, lines, opts, result); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		lines = lines[:0]
+		return nil
+	}
+
+	addRow := func(dealer *model.Dealer, line int) *model.AppError {
+		batch = append(batch, dealer)
+		lines = append(lines, line)
+		if len(batch) >= model.DEALER_BULK_IMPORT_BATCH_SIZE {
+			return flush()
+		}
+		return nil
+	}
+
+	switch format {
+	case model.DEALER_BULK_IMPORT_FORMAT_CSV:
+		reader := csv.NewReader(r)
+		header, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return result, nil
+			}
+			return result, model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.read.app_error", nil, err.Error(), http.StatusBadRequest)
+		}
+
+		line := 1
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			line++
+			if err != nil {
+				result.Errors = append(result.Errors, &model.DealerImportRowError{
+					Line:  line,
+					Error: model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.read.app_error", nil, err.Error(), http.StatusBadRequest),
+				})
+				continue
+			}
+
+			if appErr := addRow(dealerFromCSVRow(header, row), line); appErr != nil {
+				return result, appErr
+			}
+		}
+
+	case model.DEALER_BULK_IMPORT_FORMAT_JSONL:
+		scanner := bufio.NewScanner(r)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := scanner.Text()
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			var dealer model.Dealer
+			if err := json.Unmarshal([]byte(text), &dealer); err != nil {
+				result.Errors = append(result.Errors, &model.DealerImportRowError{
+					Line:  line,
+					Error: model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.read.app_error", nil, err.Error(), http.StatusBadRequest),
+					Row:   text,
+				})
+				continue
+			}
+
+			// Feeds don't carry Mattermost Ids; matching for Upsert is done
+			// by (Name, City, Province) in bulkImportBatch, never by Id. A
+			// caller-supplied Id or DeleteAt here would bypass the same
+			// safeguards Save/Update/Delete enforce, so drop them exactly
+			// like a new dealer would get from PreSave.
+			dealer.Id = ""
+			dealer.DeleteAt = 0
+
+			if appErr := addRow(&dealer, line); appErr != nil {
+				return result, appErr
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return result, model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.read.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+	default:
+		return result, model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.unsupported_format.app_error", nil, "format="+format, http.StatusBadRequest)
+	}
+
+	if appErr := flush(); appErr != nil {
+		return result, appErr
+	}
+
+	return result, nil
+}
+
+// bulkImportBatch persists one batch of rows inside a single transaction. A
+// row that fails validation or, in Upsert mode, an existing-row lookup is
+// recorded on result and skipped rather than aborting the batch.
+func (ds SqlDealerStore) bulkImportBatch(batch []*model.Dealer, lines []int, opts model.DealerBulkImportOptions, result *model.DealerBulkImportResult) *model.AppError {
+	transaction, err := ds.GetMaster().Begin()
+	if err != nil {
+		return model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer finalizeTransaction(transaction)
+
+	// Counted locally and added to result only after Commit succeeds, so a
+	// batch that fails to commit isn't reported as imported.
+	created, updated := 0, 0
+	for i, dealer := range batch {
+		line := lines[i]
+
+		var existing *model.Dealer
+		if opts.Upsert {
+			var match model.Dealer
+			findErr := transaction.SelectOne(&match,
+				"SELECT * FROM Dealer WHERE Name = :Name AND City = :City AND Province = :Province AND DeleteAt = 0",
+				map[string]interface{}{"Name": dealer.Name, "City": dealer.City, "Province": dealer.Province})
+			if findErr == nil {
+				existing = &match
+			} else if findErr != sql.ErrNoRows {
+				result.Errors = append(result.Errors, &model.DealerImportRowError{
+					Line:  line,
+					Error: model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.upsert_lookup.app_error", nil, findErr.Error(), http.StatusInternalServerError),
+				})
+				continue
+			}
+		}
+
+		if existing != nil {
+			dealer.Id = existing.Id
+			dealer.CreateAt = existing.CreateAt
+			dealer.PreUpdate()
+			if appErr := dealer.IsValid(); appErr != nil {
+				result.Errors = append(result.Errors, &model.DealerImportRowError{Line: line, Error: appErr})
+				continue
+			}
+			if _, err := transaction.Update(dealer); err != nil {
+				result.Errors = append(result.Errors, &model.DealerImportRowError{
+					Line:  line,
+					Error: model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.update.app_error", nil, err.Error(), http.StatusInternalServerError),
+				})
+				continue
+			}
+			updated++
+			continue
+		}
+
+		dealer.PreSave()
+		if appErr := dealer.IsValid(); appErr != nil {
+			result.Errors = append(result.Errors, &model.DealerImportRowError{Line: line, Error: appErr})
+			continue
+		}
+		if err := transaction.Insert(dealer); err != nil {
+			result.Errors = append(result.Errors, &model.DealerImportRowError{
+				Line:  line,
+				Error: model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.insert.app_error", nil, err.Error(), http.StatusInternalServerError),
+			})
+			continue
+		}
+		created++
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return model.NewAppError("SqlDealerStore.BulkImport", "store.sql_dealer.bulk_import.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	result.Created += created
+	result.Updated += updated
+	return nil
+}
+
+// BulkExport streams every dealer matching opts to w as "csv" or "jsonl",
+// paging through SearchDealers so tables with millions of rows are never
+// buffered in memory.
+func (ds SqlDealerStore) BulkExport(w io.Writer, format string, opts *model.DealerSearchOptions) *model.AppError {
+	exportOpts := *opts
+	exportOpts.Page = 0
+	exportOpts.PerPage = model.DEALER_SEARCH_MAX_PER_PAGE
+
+	var csvWriter *csv.Writer
+	if format == model.DEALER_BULK_IMPORT_FORMAT_CSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(dealerCSVColumns); err != nil {
+			return model.NewAppError("SqlDealerStore.BulkExport", "store.sql_dealer.bulk_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	} else if format != model.DEALER_BULK_IMPORT_FORMAT_JSONL {
+		return model.NewAppError("SqlDealerStore.BulkExport", "store.sql_dealer.bulk_export.unsupported_format.app_error", nil, "format="+format, http.StatusBadRequest)
+	}
+
+	// BulkExport is an administrative bulk operation (the OEM feed itself),
+	// not a response to an end-user request, so it gets the unsanitized row.
+	fullAccess := model.DealerSanitizeOptions(true, false)
+
+	for {
+		results, appErr := ds.SearchDealers(&exportOpts, fullAccess)
+		if appErr != nil {
+			return appErr
+		}
+		if len(results.Dealers) == 0 {
+			break
+		}
+
+		for _, dealer := range results.Dealers {
+			if format == model.DEALER_BULK_IMPORT_FORMAT_CSV {
+				row := []string{dealer.Name, dealer.PhoneNumber, dealer.Address, dealer.City, dealer.Province,
+					dealer.Country, dealer.PostalCode, strings.Join(dealer.Brands, ";")}
+				if err := csvWriter.Write(row); err != nil {
+					return model.NewAppError("SqlDealerStore.BulkExport", "store.sql_dealer.bulk_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+				}
+			} else {
+				line, err := json.Marshal(dealer)
+				if err != nil {
+					return model.NewAppError("SqlDealerStore.BulkExport", "store.sql_dealer.bulk_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+				}
+				if _, err := w.Write(append(line, '\n')); err != nil {
+					return model.NewAppError("SqlDealerStore.BulkExport", "store.sql_dealer.bulk_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+				}
+			}
+		}
+
+		if len(results.Dealers) < exportOpts.PerPage {
+			break
+		}
+		exportOpts.Page++
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return model.NewAppError("SqlDealerStore.BulkExport", "store.sql_dealer.bulk_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDealerFromCSVRow(t *testing.T) {
+	header := dealerCSVColumns
+
+	t.Run("maps every column", func(t *testing.T) {
+		row := []string{"Acme Motors", "555-0100", "123 Main St", "Springfield", "ON", "CA", "A1A 1A1", "Ford;Lincoln"}
+
+		dealer := dealerFromCSVRow(header, row)
+
+		require.Equal(t, "Acme Motors", dealer.Name)
+		require.Equal(t, "555-0100", dealer.PhoneNumber)
+		require.Equal(t, "123 Main St", dealer.Address)
+		require.Equal(t, "Springfield", dealer.City)
+		require.Equal(t, "ON", dealer.Province)
+		require.Equal(t, "CA", dealer.Country)
+		require.Equal(t, "A1A 1A1", dealer.PostalCode)
+		require.Equal(t, model.StringArray{"Ford", "Lincoln"}, dealer.Brands)
+	})
+
+	t.Run("ignores an Id column even if present", func(t *testing.T) {
+		dealer := dealerFromCSVRow([]string{"Id", "Name"}, []string{"some-id", "Acme Motors"})
+
+		require.Empty(t, dealer.Id)
+		require.Equal(t, "Acme Motors", dealer.Name)
+	})
+
+	t.Run("short rows don't panic", func(t *testing.T) {
+		dealer := dealerFromCSVRow(header, []string{"Acme Motors"})
+
+		require.Equal(t, "Acme Motors", dealer.Name)
+		require.Empty(t, dealer.PhoneNumber)
+	})
+
+	t.Run("empty brands column yields no brands", func(t *testing.T) {
+		row := []string{"Acme Motors", "", "", "", "", "", "", ""}
+
+		dealer := dealerFromCSVRow(header, row)
+
+		require.Empty(t, dealer.Brands)
+	})
+}
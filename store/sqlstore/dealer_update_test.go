@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyUpdateOwnership drives the actual fix for Update discarding
+// patched fields: applyUpdateOwnership must reassert only the server-owned
+// fields and leave everything else - including whatever a prior Dealer.Patch
+// applied - exactly as the caller set it.
+func TestApplyUpdateOwnership(t *testing.T) {
+	oldDealer := &model.Dealer{
+		Id:       "old-id",
+		CreateAt: 1,
+		DeleteAt: 0,
+		Name:     "Old Name",
+	}
+
+	t.Run("patched fields survive, CreateAt is always server-owned", func(t *testing.T) {
+		dealer := &model.Dealer{
+			Id:       "old-id",
+			CreateAt: 999, // caller tampered with this
+			DeleteAt: 0,
+			Name:     "Patched Name",
+		}
+
+		applyUpdateOwnership(dealer, oldDealer, false)
+
+		require.Equal(t, "Patched Name", dealer.Name, "a patched field must not be clobbered by Update")
+		require.Equal(t, oldDealer.CreateAt, dealer.CreateAt)
+	})
+
+	t.Run("untrusted callers can't move Id or DeleteAt", func(t *testing.T) {
+		dealer := &model.Dealer{
+			Id:       "caller-supplied-id",
+			CreateAt: 1,
+			DeleteAt: 12345,
+			Name:     "Patched Name",
+		}
+
+		applyUpdateOwnership(dealer, oldDealer, false)
+
+		require.Equal(t, oldDealer.Id, dealer.Id)
+		require.Equal(t, oldDealer.DeleteAt, dealer.DeleteAt)
+	})
+
+	t.Run("trusted callers may set Id and DeleteAt", func(t *testing.T) {
+		dealer := &model.Dealer{
+			Id:       "trusted-id",
+			CreateAt: 1,
+			DeleteAt: 12345,
+			Name:     "Patched Name",
+		}
+
+		applyUpdateOwnership(dealer, oldDealer, true)
+
+		require.Equal(t, "trusted-id", dealer.Id)
+		require.Equal(t, int64(12345), dealer.DeleteAt)
+	})
+}